@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	RoleAdmin  = "admin"
+	RoleViewer = "viewer"
+
+	defaultAccessTokenTTL = 15 * time.Minute
+
+	// maxRefreshSessionAge bounds how long a token's original iat can be
+	// refreshed against. Without this, a leaked token could be refreshed
+	// forever, defeating the short access-token TTL.
+	maxRefreshSessionAge = 24 * time.Hour
+)
+
+type contextKey string
+
+const claimsContextKey contextKey = "claims"
+
+// Claims are the JWT claims issued by /auth/login and /auth/refresh.
+type Claims struct {
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// credential is a statically configured login, loaded from AUTH_USERS.
+type credential struct {
+	Username string
+	Password string
+	Role     string
+}
+
+var (
+	jwtSecret []byte
+	authUsers []credential
+)
+
+// loadAuthConfig reads JWT_SECRET and AUTH_USERS ("user:pass:role,...").
+// Both are required: an empty JWT_SECRET would sign and verify every token
+// with an empty HMAC key, and an unset AUTH_USERS would otherwise need a
+// guessable built-in account. AUTH_ALLOW_DEV_DEFAULTS=true opts into the
+// latter for local development, logging a loud warning when it does.
+func loadAuthConfig() {
+	jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+	if len(jwtSecret) == 0 {
+		log.Fatal("JWT_SECRET must be set; refusing to start with an empty signing key")
+	}
+
+	raw := os.Getenv("AUTH_USERS")
+	if raw == "" {
+		if os.Getenv("AUTH_ALLOW_DEV_DEFAULTS") != "true" {
+			log.Fatal("AUTH_USERS must be set (or AUTH_ALLOW_DEV_DEFAULTS=true for local development only)")
+		}
+		log.Println("WARNING: AUTH_USERS is unset; falling back to insecure default admin/admin and viewer/viewer credentials because AUTH_ALLOW_DEV_DEFAULTS=true")
+		authUsers = []credential{
+			{Username: "admin", Password: "admin", Role: RoleAdmin},
+			{Username: "viewer", Password: "viewer", Role: RoleViewer},
+		}
+		return
+	}
+
+	for _, entry := range strings.Split(raw, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		authUsers = append(authUsers, credential{Username: parts[0], Password: parts[1], Role: parts[2]})
+	}
+}
+
+func findUser(username, password string) (credential, bool) {
+	for _, u := range authUsers {
+		if u.Username == username && u.Password == password {
+			return u, true
+		}
+	}
+	return credential{}, false
+}
+
+// issueToken signs an HS256 JWT with sub, role, iat and exp claims.
+func issueToken(sub, role string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(defaultAccessTokenTTL)
+
+	claims := Claims{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   sub,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString(jwtSecret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error signing token: %v", err)
+	}
+	return signed, expiresAt, nil
+}
+
+// parseToken validates a bearer token's signature and, unless
+// allowExpired is true, its expiry.
+func parseToken(tokenString string, allowExpired bool) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		return jwtSecret, nil
+	}, jwt.WithValidMethods([]string{"HS256"}))
+
+	if err != nil {
+		if allowExpired && errors.Is(err, jwt.ErrTokenExpired) {
+			return claims, nil
+		}
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return claims, nil
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if !strings.HasPrefix(header, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(header, prefix), true
+}
+
+// authMiddleware enforces bearer-token JWT authentication and role-based
+// access: viewers may only GET, admins may do anything. It's registered on
+// the /students subrouter.
+func authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenString, ok := bearerToken(r)
+		if !ok {
+			http.Error(w, "Missing bearer token", http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := parseToken(tokenString, false)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid token: %v", err), http.StatusUnauthorized)
+			return
+		}
+
+		if r.Method != http.MethodGet && claims.Role != RoleAdmin {
+			http.Error(w, "Insufficient role", http.StatusForbidden)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), claimsContextKey, claims)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type tokenResponse struct {
+	Token     string    `json:"token"`
+	Role      string    `json:"role"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func authLogin(w http.ResponseWriter, r *http.Request) {
+	var req loginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, ok := findUser(req.Username, req.Password)
+	if !ok {
+		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	token, expiresAt, err := issueToken(user.Username, user.Role)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokenResponse{Token: token, Role: user.Role, ExpiresAt: expiresAt})
+}
+
+// authRefresh issues a fresh token for a still-valid or recently-expired
+// bearer token, preserving its subject and role.
+func authRefresh(w http.ResponseWriter, r *http.Request) {
+	tokenString, ok := bearerToken(r)
+	if !ok {
+		http.Error(w, "Missing bearer token", http.StatusUnauthorized)
+		return
+	}
+
+	claims, err := parseToken(tokenString, true)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid token: %v", err), http.StatusUnauthorized)
+		return
+	}
+
+	if claims.IssuedAt == nil || time.Since(claims.IssuedAt.Time) > maxRefreshSessionAge {
+		http.Error(w, "Session too old to refresh; please log in again", http.StatusUnauthorized)
+		return
+	}
+
+	token, expiresAt, err := issueToken(claims.Subject, claims.Role)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokenResponse{Token: token, Role: claims.Role, ExpiresAt: expiresAt})
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func withTestAuthConfig(t *testing.T) {
+	t.Helper()
+	prevSecret, prevUsers := jwtSecret, authUsers
+	jwtSecret = []byte("test-secret")
+	authUsers = []credential{
+		{Username: "admin", Password: "admin", Role: RoleAdmin},
+		{Username: "viewer", Password: "viewer", Role: RoleViewer},
+	}
+	t.Cleanup(func() {
+		jwtSecret, authUsers = prevSecret, prevUsers
+	})
+}
+
+func TestIssueAndParseToken(t *testing.T) {
+	withTestAuthConfig(t)
+
+	token, expiresAt, err := issueToken("admin", RoleAdmin)
+	if err != nil {
+		t.Fatalf("issueToken: %v", err)
+	}
+	if !expiresAt.After(time.Now()) {
+		t.Fatalf("expiresAt = %v, want a time in the future", expiresAt)
+	}
+
+	claims, err := parseToken(token, false)
+	if err != nil {
+		t.Fatalf("parseToken: %v", err)
+	}
+	if claims.Subject != "admin" || claims.Role != RoleAdmin {
+		t.Errorf("claims = %+v, want subject=admin role=%s", claims, RoleAdmin)
+	}
+}
+
+func TestParseTokenExpired(t *testing.T) {
+	withTestAuthConfig(t)
+
+	claims := Claims{
+		Role: RoleViewer,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "viewer",
+			IssuedAt:  jwt.NewNumericDate(time.Now().Add(-2 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Hour)),
+		},
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtSecret)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+
+	if _, err := parseToken(signed, false); err == nil {
+		t.Fatal("parseToken(allowExpired=false) on an expired token: want error, got nil")
+	}
+
+	got, err := parseToken(signed, true)
+	if err != nil {
+		t.Fatalf("parseToken(allowExpired=true): %v", err)
+	}
+	if got.Subject != "viewer" {
+		t.Errorf("Subject = %q, want %q", got.Subject, "viewer")
+	}
+}
+
+func TestAuthRefreshRejectsOldSession(t *testing.T) {
+	withTestAuthConfig(t)
+
+	claims := Claims{
+		Role: RoleViewer,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "viewer",
+			IssuedAt:  jwt.NewNumericDate(time.Now().Add(-maxRefreshSessionAge - time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Hour)),
+		},
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtSecret)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/refresh", nil)
+	req.Header.Set("Authorization", "Bearer "+signed)
+	rec := httptest.NewRecorder()
+	authRefresh(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d (session older than maxRefreshSessionAge)", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	withTestAuthConfig(t)
+
+	adminToken, _, err := issueToken("admin", RoleAdmin)
+	if err != nil {
+		t.Fatalf("issueToken admin: %v", err)
+	}
+	viewerToken, _, err := issueToken("viewer", RoleViewer)
+	if err != nil {
+		t.Fatalf("issueToken viewer: %v", err)
+	}
+
+	next := authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		method     string
+		authHeader string
+		wantStatus int
+	}{
+		{"missing token", http.MethodGet, "", http.StatusUnauthorized},
+		{"invalid token", http.MethodGet, "Bearer not-a-jwt", http.StatusUnauthorized},
+		{"viewer can GET", http.MethodGet, "Bearer " + viewerToken, http.StatusOK},
+		{"viewer cannot POST", http.MethodPost, "Bearer " + viewerToken, http.StatusForbidden},
+		{"admin can POST", http.MethodPost, "Bearer " + adminToken, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/students", nil)
+			if tt.authHeader != "" {
+				req.Header.Set("Authorization", tt.authHeader)
+			}
+			rec := httptest.NewRecorder()
+			next.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAuthLoginRejectsBadCredentials(t *testing.T) {
+	withTestAuthConfig(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"username":"admin","password":"wrong"}`))
+	rec := httptest.NewRecorder()
+	authLogin(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
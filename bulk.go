@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// bulkResult reports the outcome of creating a single row from a
+// POST /students/bulk request, so a client can tell which rows of a
+// partially-failed batch need fixing.
+type bulkResult struct {
+	Index  int    `json:"index"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// bulkCreateStudents accepts a batch of students as a JSON array
+// (application/json), text/csv, or application/x-ndjson, creating each row
+// against the Store and reporting a per-row result. Each row is created
+// transactionally — createStudentRow's write is a single atomic statement
+// against the Store (SQLStore relies on the students.id primary key to
+// accept-or-reject a row as one unit; see SQLStore.Create), so a row is
+// never left half-written. Rows are NOT wrapped in one batch-wide
+// transaction: the request asks for per-row results so partial failures
+// are reportable, and an all-or-nothing transaction would force rolling
+// back every successfully-created row the moment any other row fails,
+// which is the opposite of that.
+func bulkCreateStudents(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil && r.Header.Get("Content-Type") != "" {
+		http.Error(w, fmt.Sprintf("invalid Content-Type: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var results []bulkResult
+	switch mediaType {
+	case "text/csv":
+		results, err = bulkCreateFromCSV(r.Context(), r.Body)
+	case "application/x-ndjson":
+		results, err = bulkCreateFromNDJSON(r.Context(), r.Body)
+	case "", "application/json":
+		results, err = bulkCreateFromJSON(r.Context(), r.Body)
+	default:
+		http.Error(w, fmt.Sprintf("unsupported Content-Type: %s", mediaType), http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func bulkCreateFromJSON(ctx context.Context, body io.Reader) ([]bulkResult, error) {
+	var students []Student
+	if err := json.NewDecoder(body).Decode(&students); err != nil {
+		return nil, fmt.Errorf("invalid JSON array: %v", err)
+	}
+
+	results := make([]bulkResult, len(students))
+	for i, student := range students {
+		results[i] = createStudentRow(ctx, i, student)
+	}
+	return results, nil
+}
+
+func bulkCreateFromNDJSON(ctx context.Context, body io.Reader) ([]bulkResult, error) {
+	results := []bulkResult{}
+	scanner := bufio.NewScanner(body)
+
+	index := 0
+	for ; scanner.Scan(); index++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var student Student
+		if err := json.Unmarshal([]byte(line), &student); err != nil {
+			results = append(results, bulkResult{Index: index, Status: "error", Error: fmt.Sprintf("invalid JSON: %v", err)})
+			continue
+		}
+		results = append(results, createStudentRow(ctx, index, student))
+	}
+
+	// A scan failure (e.g. a line exceeding bufio's token limit) aborts
+	// reading, but rows already created above must still be reported
+	// rather than discarded.
+	if err := scanner.Err(); err != nil {
+		results = append(results, bulkResult{Index: index, Status: "error", Error: fmt.Sprintf("error reading ndjson body: %v", err)})
+	}
+	return results, nil
+}
+
+func bulkCreateFromCSV(ctx context.Context, body io.Reader) ([]bulkResult, error) {
+	reader := csv.NewReader(body)
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return []bulkResult{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading CSV header: %v", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"id", "name", "age", "email"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing required CSV column %q", required)
+		}
+	}
+
+	results := []bulkResult{}
+	for index := 0; ; index++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			results = append(results, bulkResult{Index: index, Status: "error", Error: fmt.Sprintf("error reading row: %v", err)})
+			continue
+		}
+
+		student, err := studentFromCSVRecord(record, columns)
+		if err != nil {
+			results = append(results, bulkResult{Index: index, Status: "error", Error: err.Error()})
+			continue
+		}
+		results = append(results, createStudentRow(ctx, index, student))
+	}
+	return results, nil
+}
+
+func studentFromCSVRecord(record []string, columns map[string]int) (Student, error) {
+	id, err := strconv.Atoi(record[columns["id"]])
+	if err != nil {
+		return Student{}, fmt.Errorf("invalid id: %v", err)
+	}
+	age, err := strconv.Atoi(record[columns["age"]])
+	if err != nil {
+		return Student{}, fmt.Errorf("invalid age: %v", err)
+	}
+
+	return Student{
+		ID:    id,
+		Name:  record[columns["name"]],
+		Age:   age,
+		Email: record[columns["email"]],
+	}, nil
+}
+
+// createStudentRow validates and creates a single bulk row, translating the
+// same rules createStudent enforces into a bulkResult instead of an HTTP
+// response.
+func createStudentRow(ctx context.Context, index int, student Student) bulkResult {
+	if msg := validateStudent(student); msg != "" {
+		return bulkResult{Index: index, Status: "error", Error: msg}
+	}
+
+	if err := store.Create(ctx, student); err != nil {
+		if errors.Is(err, ErrStudentExists) {
+			return bulkResult{Index: index, Status: "error", Error: "student ID already exists"}
+		}
+		return bulkResult{Index: index, Status: "error", Error: err.Error()}
+	}
+	return bulkResult{Index: index, Status: "created"}
+}
+
+// exportStudents streams the full student collection as format=json (the
+// default), csv or ndjson, reading it row by row from store.Stream and
+// writing each row to w as it's encoded rather than materializing the whole
+// collection first.
+func exportStudents(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+
+	var err error
+	switch format {
+	case "csv":
+		err = exportStudentsCSV(r.Context(), w)
+	case "ndjson":
+		err = exportStudentsNDJSON(r.Context(), w)
+	case "json":
+		err = exportStudentsJSON(r.Context(), w)
+	default:
+		http.Error(w, fmt.Sprintf("unsupported format: %s", format), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		logger.Error().Str("request_id", requestIDFromContext(r.Context())).Str("format", format).Err(err).Msg("error exporting students")
+	}
+}
+
+func exportStudentsCSV(ctx context.Context, w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/csv")
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"id", "name", "age", "email"}); err != nil {
+		return err
+	}
+
+	if err := store.Stream(ctx, func(student Student) error {
+		return writer.Write([]string{strconv.Itoa(student.ID), student.Name, strconv.Itoa(student.Age), student.Email})
+	}); err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func exportStudentsNDJSON(ctx context.Context, w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+
+	enc := json.NewEncoder(w)
+	return store.Stream(ctx, func(student Student) error {
+		return enc.Encode(student)
+	})
+}
+
+func exportStudentsJSON(ctx context.Context, w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	first := true
+	if err := store.Stream(ctx, func(student Student) error {
+		if !first {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		first = false
+		return enc.Encode(student)
+	}); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "]")
+	return err
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func withTestStore(t *testing.T) Store {
+	t.Helper()
+	prev := store
+	store = NewInMemoryStore()
+	t.Cleanup(func() { store = prev })
+	return store
+}
+
+func TestBulkCreateFromJSON(t *testing.T) {
+	withTestStore(t)
+
+	body := `[
+		{"id":10000001,"name":"Alice","age":20,"email":"alice@example.com"},
+		{"id":10000001,"name":"Duplicate","age":20,"email":"dup@example.com"},
+		{"id":1,"name":"Bad ID","age":20,"email":"bad@example.com"}
+	]`
+	results, err := bulkCreateFromJSON(context.Background(), strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("bulkCreateFromJSON: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("len(results) = %d, want 3", len(results))
+	}
+	if results[0].Status != "created" {
+		t.Errorf("results[0].Status = %q, want created", results[0].Status)
+	}
+	if results[1].Status != "error" {
+		t.Errorf("results[1].Status = %q, want error (duplicate ID)", results[1].Status)
+	}
+	if results[2].Status != "error" {
+		t.Errorf("results[2].Status = %q, want error (invalid ID)", results[2].Status)
+	}
+}
+
+func TestBulkCreateFromCSV(t *testing.T) {
+	withTestStore(t)
+
+	body := "id,name,age,email\n10000002,Bob,22,bob@example.com\nnot-a-number,Bad,22,bad@example.com\n"
+	results, err := bulkCreateFromCSV(context.Background(), strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("bulkCreateFromCSV: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("len(results) = %d, want 2", len(results))
+	}
+	if results[0].Status != "created" {
+		t.Errorf("results[0].Status = %q, want created", results[0].Status)
+	}
+	if results[1].Status != "error" {
+		t.Errorf("results[1].Status = %q, want error (invalid row)", results[1].Status)
+	}
+}
+
+func TestBulkCreateEmptyBodyReturnsEmptyArray(t *testing.T) {
+	withTestStore(t)
+
+	ndjson, err := bulkCreateFromNDJSON(context.Background(), strings.NewReader(""))
+	if err != nil {
+		t.Fatalf("bulkCreateFromNDJSON: %v", err)
+	}
+	if ndjson == nil || len(ndjson) != 0 {
+		t.Errorf("bulkCreateFromNDJSON(empty) = %#v, want non-nil empty slice", ndjson)
+	}
+
+	csv, err := bulkCreateFromCSV(context.Background(), strings.NewReader(""))
+	if err != nil {
+		t.Fatalf("bulkCreateFromCSV: %v", err)
+	}
+	if csv == nil || len(csv) != 0 {
+		t.Errorf("bulkCreateFromCSV(empty) = %#v, want non-nil empty slice", csv)
+	}
+
+	ndjsonJSON, err := json.Marshal(ndjson)
+	if err != nil {
+		t.Fatalf("json.Marshal(ndjson): %v", err)
+	}
+	csvJSON, err := json.Marshal(csv)
+	if err != nil {
+		t.Fatalf("json.Marshal(csv): %v", err)
+	}
+	if string(ndjsonJSON) != "[]" || string(csvJSON) != "[]" {
+		t.Error("empty results must encode as [], not null, so clients parsing an array don't break")
+	}
+}
+
+func TestExportStudentsRoundTrip(t *testing.T) {
+	s := withTestStore(t)
+	ctx := context.Background()
+
+	want := []Student{
+		{ID: 10000003, Name: "Carol", Age: 23, Email: "carol@example.com"},
+		{ID: 10000004, Name: "Dave", Age: 24, Email: "dave@example.com"},
+	}
+	for _, student := range want {
+		if err := s.Create(ctx, student); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	for _, format := range []string{"csv", "ndjson", "json"} {
+		t.Run(format, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/students/export?format="+format, nil)
+			rec := httptest.NewRecorder()
+			exportStudents(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			body := rec.Body.String()
+			for _, student := range want {
+				if !bytes.Contains([]byte(body), []byte(student.Name)) {
+					t.Errorf("export(%s) missing %q:\n%s", format, student.Name, body)
+				}
+			}
+		})
+	}
+}
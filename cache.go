@@ -0,0 +1,185 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultSummaryCacheTTL        = time.Hour
+	defaultSummaryCacheMaxEntries = 1000
+)
+
+var (
+	summaryCache *summaryLRUCache
+	summaryGroup singleflight.Group
+)
+
+// initSummaryCache builds the package-wide summary cache, sized and aged by
+// SUMMARY_CACHE_TTL (default 1h, Go duration syntax) and
+// SUMMARY_CACHE_MAX_ENTRIES (default 1000).
+func initSummaryCache() {
+	ttl := defaultSummaryCacheTTL
+	if v := os.Getenv("SUMMARY_CACHE_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			ttl = d
+		}
+	}
+
+	maxEntries := defaultSummaryCacheMaxEntries
+	if v := os.Getenv("SUMMARY_CACHE_MAX_ENTRIES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			maxEntries = n
+		}
+	}
+
+	summaryCache = newSummaryLRUCache(maxEntries, ttl)
+}
+
+// summaryCacheKey hashes the fields a summary depends on, so a change to any
+// of them (including switching models) misses the cache instead of serving a
+// stale summary.
+func summaryCacheKey(student Student, model string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d|%s|%d|%s|%s", student.ID, student.Name, student.Age, student.Email, model)))
+	return hex.EncodeToString(sum[:])
+}
+
+// cachedGenerateSummary serves summaryProvider.Generate from the in-process
+// cache when possible, and coalesces concurrent misses for the same student
+// into a single upstream call via singleflight.
+func cachedGenerateSummary(ctx context.Context, provider SummaryProvider, student Student) (string, error) {
+	key := summaryCacheKey(student, provider.Model())
+
+	if summary, ok := summaryCache.get(key); ok {
+		summaryCacheHits.Inc()
+		return summary, nil
+	}
+	summaryCacheMisses.Inc()
+
+	result, err, _ := summaryGroup.Do(key, func() (interface{}, error) {
+		summary, err := provider.Generate(ctx, student)
+		if err != nil {
+			return "", err
+		}
+		summaryCache.set(key, student.ID, summary)
+		return summary, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.(string), nil
+}
+
+// invalidateSummaryCache drops every cached summary for a student, called
+// whenever their profile is updated or deleted.
+func invalidateSummaryCache(studentID int) {
+	summaryCache.invalidateStudent(studentID)
+}
+
+type summaryCacheEntry struct {
+	key       string
+	studentID int
+	summary   string
+	expiresAt time.Time
+}
+
+// summaryLRUCache is a fixed-size, TTL'd cache of generated summaries. It's
+// safe for concurrent use.
+type summaryLRUCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	ll         *list.List
+	items      map[string]*list.Element
+	byStudent  map[int]map[string]struct{}
+}
+
+func newSummaryLRUCache(maxEntries int, ttl time.Duration) *summaryLRUCache {
+	return &summaryLRUCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+		byStudent:  make(map[int]map[string]struct{}),
+	}
+}
+
+func (c *summaryLRUCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return "", false
+	}
+
+	entry := elem.Value.(*summaryCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.removeElement(elem)
+		return "", false
+	}
+
+	c.ll.MoveToFront(elem)
+	return entry.summary, true
+}
+
+func (c *summaryLRUCache) set(key string, studentID int, summary string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*summaryCacheEntry)
+		entry.summary = summary
+		entry.expiresAt = time.Now().Add(c.ttl)
+		c.ll.MoveToFront(elem)
+		return
+	}
+
+	entry := &summaryCacheEntry{key: key, studentID: studentID, summary: summary, expiresAt: time.Now().Add(c.ttl)}
+	elem := c.ll.PushFront(entry)
+	c.items[key] = elem
+
+	if c.byStudent[studentID] == nil {
+		c.byStudent[studentID] = make(map[string]struct{})
+	}
+	c.byStudent[studentID][key] = struct{}{}
+
+	if c.ll.Len() > c.maxEntries {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+func (c *summaryLRUCache) invalidateStudent(studentID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.byStudent[studentID] {
+		if elem, ok := c.items[key]; ok {
+			c.removeElement(elem)
+		}
+	}
+}
+
+// removeElement drops elem from the list, the key index and the
+// per-student index. Callers must hold c.mu.
+func (c *summaryLRUCache) removeElement(elem *list.Element) {
+	entry := elem.Value.(*summaryCacheEntry)
+	c.ll.Remove(elem)
+	delete(c.items, entry.key)
+
+	if keys := c.byStudent[entry.studentID]; keys != nil {
+		delete(keys, entry.key)
+		if len(keys) == 0 {
+			delete(c.byStudent, entry.studentID)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummaryLRUCacheGetSet(t *testing.T) {
+	c := newSummaryLRUCache(10, time.Hour)
+
+	if _, ok := c.get("missing"); ok {
+		t.Error("get on empty cache: want ok=false")
+	}
+
+	c.set("key", 1, "a summary")
+	got, ok := c.get("key")
+	if !ok || got != "a summary" {
+		t.Errorf("get = (%q, %v), want (%q, true)", got, ok, "a summary")
+	}
+}
+
+func TestSummaryLRUCacheExpires(t *testing.T) {
+	c := newSummaryLRUCache(10, -time.Second) // already-expired TTL
+
+	c.set("key", 1, "stale")
+	if _, ok := c.get("key"); ok {
+		t.Error("get on an expired entry: want ok=false")
+	}
+}
+
+func TestSummaryLRUCacheEvictsOldest(t *testing.T) {
+	c := newSummaryLRUCache(2, time.Hour)
+
+	c.set("a", 1, "A")
+	c.set("b", 2, "B")
+	c.set("c", 3, "C") // evicts "a", the least recently used
+
+	if _, ok := c.get("a"); ok {
+		t.Error("get(a) after eviction: want ok=false")
+	}
+	if _, ok := c.get("b"); !ok {
+		t.Error("get(b) after eviction: want ok=true")
+	}
+	if _, ok := c.get("c"); !ok {
+		t.Error("get(c) after eviction: want ok=true")
+	}
+}
+
+func TestSummaryLRUCacheInvalidateStudent(t *testing.T) {
+	c := newSummaryLRUCache(10, time.Hour)
+
+	c.set("student1-modelA", 1, "summary A")
+	c.set("student1-modelB", 1, "summary B")
+	c.set("student2-modelA", 2, "other student")
+
+	c.invalidateStudent(1)
+
+	if _, ok := c.get("student1-modelA"); ok {
+		t.Error("get(student1-modelA) after invalidateStudent(1): want ok=false")
+	}
+	if _, ok := c.get("student1-modelB"); ok {
+		t.Error("get(student1-modelB) after invalidateStudent(1): want ok=false")
+	}
+	if _, ok := c.get("student2-modelA"); !ok {
+		t.Error("get(student2-modelA) after invalidateStudent(1): want ok=true, other students' entries must survive")
+	}
+}
+
+func TestSummaryCacheKeyChangesWithModel(t *testing.T) {
+	student := Student{ID: 10000001, Name: "Alice", Age: 20, Email: "alice@example.com"}
+
+	if summaryCacheKey(student, "model-a") == summaryCacheKey(student, "model-b") {
+		t.Error("summaryCacheKey should differ across models, so switching models misses the cache")
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// fakeStreamingProvider is a StreamingSummaryProvider test double that
+// replays a fixed sequence of chunks instead of calling a real LLM backend.
+type fakeStreamingProvider struct {
+	chunks []string
+	model  string
+}
+
+func (p *fakeStreamingProvider) Model() string { return p.model }
+
+func (p *fakeStreamingProvider) Generate(ctx context.Context, student Student) (string, error) {
+	return strings.Join(p.chunks, ""), nil
+}
+
+func (p *fakeStreamingProvider) Stream(ctx context.Context, student Student, onChunk func(chunk string) error) error {
+	for _, c := range p.chunks {
+		if err := onChunk(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func withTestSummaryProvider(t *testing.T, provider SummaryProvider) {
+	t.Helper()
+	prevProvider, prevReady := summaryProvider, providerReady
+	summaryProvider, providerReady = provider, true
+	t.Cleanup(func() { summaryProvider, providerReady = prevProvider, prevReady })
+}
+
+// TestGetStudentSummaryStreamsSSEThroughMetricsMiddleware drives the real
+// handler (wrapped in metricsMiddleware, as it's registered in main) with
+// Accept: text/event-stream, guarding against statusRecorder failing to
+// forward http.Flusher and silently downgrading every SSE request to a 500.
+func TestGetStudentSummaryStreamsSSEThroughMetricsMiddleware(t *testing.T) {
+	s := withTestStore(t)
+	student := Student{ID: 10000005, Name: "Eve", Age: 25, Email: "eve@example.com"}
+	if err := s.Create(context.Background(), student); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	withTestSummaryProvider(t, &fakeStreamingProvider{chunks: []string{"a ", "bright student"}, model: "fake"})
+
+	handler := metricsMiddleware(http.HandlerFunc(getStudentSummary))
+
+	req := httptest.NewRequest(http.MethodGet, "/students/10000005/summary", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req = mux.SetURLVars(req, map[string]string{"id": "10000005"})
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body:\n%s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	body := rec.Body.String()
+	wantFrames := []string{`data: {"response":"a "}` + "\n\n", `data: {"response":"bright student"}` + "\n\n"}
+	for _, want := range wantFrames {
+		if !strings.Contains(body, want) {
+			t.Errorf("body missing SSE frame %q; got:\n%s", want, body)
+		}
+	}
+}
+
+func TestWantsEventStream(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/students/1/summary", nil)
+	if wantsEventStream(req) {
+		t.Error("wantsEventStream with no Accept header: want false")
+	}
+
+	req.Header.Set("Accept", "text/event-stream")
+	if !wantsEventStream(req) {
+		t.Error("wantsEventStream with Accept: text/event-stream: want true")
+	}
+}
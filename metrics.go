@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog"
+)
+
+const requestIDHeader = "X-Request-ID"
+const requestIDContextKey contextKey = "request_id"
+
+var logger zerolog.Logger
+
+// initLogger sets up the package-wide structured logger, emitting JSON
+// lines to stdout.
+func initLogger() {
+	logger = zerolog.New(os.Stdout).With().Timestamp().Logger()
+}
+
+var (
+	httpRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Total HTTP requests processed, labeled by route, method and status.",
+		},
+		[]string{"route", "method", "status"},
+	)
+
+	httpRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "http_request_duration_seconds",
+			Help: "HTTP request latency in seconds.",
+		},
+		[]string{"route", "method"},
+	)
+
+	ollamaSummaryDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "ollama_summary_duration_seconds",
+			Help: "Latency of summary generation calls, labeled by provider and outcome.",
+		},
+		[]string{"provider", "outcome"},
+	)
+
+	summaryCacheHits = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "summary_cache_hits_total",
+			Help: "Summary requests served from the in-process cache.",
+		},
+	)
+
+	summaryCacheMisses = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "summary_cache_misses_total",
+			Help: "Summary requests that missed the in-process cache and hit the provider.",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(httpRequestsTotal, httpRequestDuration, ollamaSummaryDuration, summaryCacheHits, summaryCacheMisses)
+}
+
+// observeSummaryDuration records how long a summary generation call took,
+// labeled by provider and whether it succeeded.
+func observeSummaryDuration(provider string, start time.Time, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+	}
+	ollamaSummaryDuration.WithLabelValues(provider, outcome).Observe(time.Since(start).Seconds())
+}
+
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// requestIDMiddleware assigns a request ID (from X-Request-ID, or a newly
+// generated one) to the request context and echoes it back on the response.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+
+		ctx := context.WithValue(r.Context(), requestIDContextKey, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(code int) {
+	rec.status = code
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+// Flush forwards to the wrapped ResponseWriter's Flusher, if it implements
+// one. Embedding http.ResponseWriter as an interface field only promotes
+// the methods declared on that interface, so without this statusRecorder
+// would silently fail http.Flusher type assertions (breaking SSE/streaming
+// handlers) even though the real writer underneath supports flushing.
+func (rec *statusRecorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// metricsMiddleware records http_requests_total and
+// http_request_duration_seconds for every request, and logs a structured
+// summary line including the propagated request ID.
+func metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		route := r.URL.Path
+		if m := mux.CurrentRoute(r); m != nil {
+			if tmpl, err := m.GetPathTemplate(); err == nil {
+				route = tmpl
+			}
+		}
+
+		duration := time.Since(start)
+		httpRequestsTotal.WithLabelValues(route, r.Method, strconv.Itoa(rec.status)).Inc()
+		httpRequestDuration.WithLabelValues(route, r.Method).Observe(duration.Seconds())
+
+		logger.Info().
+			Str("request_id", requestIDFromContext(r.Context())).
+			Str("route", route).
+			Str("method", r.Method).
+			Int("status", rec.status).
+			Dur("latency", duration).
+			Msg("http request")
+	})
+}
+
+func metricsHandler() http.Handler {
+	return promhttp.Handler()
+}
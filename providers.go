@@ -0,0 +1,476 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultProviderTimeout = 60 * time.Second
+	maxProviderRetries     = 2
+)
+
+// SummaryProvider generates a natural-language summary for a student profile
+// using a particular LLM backend. Implementations are selected at startup
+// by SUMMARY_PROVIDER and configured via LLM_API_KEY, LLM_MODEL and
+// LLM_BASE_URL.
+type SummaryProvider interface {
+	Generate(ctx context.Context, student Student) (string, error)
+
+	// Model returns the configured model name, used to namespace the
+	// summary cache so switching LLM_MODEL doesn't serve stale summaries.
+	Model() string
+}
+
+// StreamingSummaryProvider is implemented by providers that can stream
+// incremental tokens as they are generated, for use with SSE clients.
+type StreamingSummaryProvider interface {
+	SummaryProvider
+	Stream(ctx context.Context, student Student, onChunk func(chunk string) error) error
+}
+
+func summaryPrompt(student Student) string {
+	return fmt.Sprintf("Summarize this student profile using only the provided details. Be brief, accurate, and creative:\n\nProfile:\n- Name: %s\n- Age: %d\n- Email: %s\n\nNote: Make the summary catchy and to the point without adding any extra information.", student.Name, student.Age, student.Email)
+}
+
+// newSummaryProvider builds the SummaryProvider selected by SUMMARY_PROVIDER
+// (default "ollama"), configured from LLM_API_KEY, LLM_MODEL and
+// LLM_BASE_URL. The Ollama provider falls back to OLLAMA_HOST when
+// LLM_BASE_URL is unset, preserving existing deployments.
+func newSummaryProvider() (SummaryProvider, error) {
+	name := strings.ToLower(os.Getenv("SUMMARY_PROVIDER"))
+	if name == "" {
+		name = "ollama"
+	}
+
+	apiKey := os.Getenv("LLM_API_KEY")
+	model := os.Getenv("LLM_MODEL")
+	baseURL := os.Getenv("LLM_BASE_URL")
+	client := &http.Client{Timeout: defaultProviderTimeout}
+
+	switch name {
+	case "ollama":
+		if baseURL == "" {
+			baseURL = ollamaHost
+		}
+		if model == "" {
+			model = "llama2"
+		}
+		return &OllamaProvider{baseURL: baseURL, model: model, client: client}, nil
+
+	case "openai":
+		if apiKey == "" {
+			return nil, fmt.Errorf("LLM_API_KEY is required for the openai provider")
+		}
+		if baseURL == "" {
+			baseURL = "https://api.openai.com"
+		}
+		if model == "" {
+			model = "gpt-3.5-turbo"
+		}
+		return &OpenAIProvider{baseURL: baseURL, model: model, apiKey: apiKey, client: client}, nil
+
+	case "anthropic":
+		if apiKey == "" {
+			return nil, fmt.Errorf("LLM_API_KEY is required for the anthropic provider")
+		}
+		if baseURL == "" {
+			baseURL = "https://api.anthropic.com"
+		}
+		if model == "" {
+			model = "claude-3-haiku-20240307"
+		}
+		return &AnthropicProvider{baseURL: baseURL, model: model, apiKey: apiKey, client: client}, nil
+
+	case "google":
+		if apiKey == "" {
+			return nil, fmt.Errorf("LLM_API_KEY is required for the google provider")
+		}
+		if baseURL == "" {
+			baseURL = "https://generativelanguage.googleapis.com"
+		}
+		if model == "" {
+			model = "gemini-1.5-flash"
+		}
+		return &GoogleProvider{baseURL: baseURL, model: model, apiKey: apiKey, client: client}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown SUMMARY_PROVIDER %q", name)
+	}
+}
+
+// doWithRetry executes req, retrying transient failures (network errors and
+// 5xx responses) up to maxProviderRetries times with a short backoff. The
+// caller must close the returned response body.
+func doWithRetry(client *http.Client, req *http.Request) (*http.Response, error) {
+	var lastErr error
+	for attempt := 0; attempt <= maxProviderRetries; attempt++ {
+		if attempt > 0 {
+			if req.GetBody == nil {
+				return nil, lastErr
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+			time.Sleep(time.Duration(attempt) * 200 * time.Millisecond)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			if req.Context().Err() != nil {
+				return nil, req.Context().Err()
+			}
+			continue
+		}
+		if resp.StatusCode < 500 {
+			return resp, nil
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		lastErr = fmt.Errorf("server returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil, lastErr
+}
+
+// ollamaStreamChunk is one line of Ollama's newline-delimited JSON streaming
+// response from /api/generate.
+type ollamaStreamChunk struct {
+	Response string `json:"response"`
+	Done     bool   `json:"done"`
+}
+
+// OllamaProvider talks to a local or remote Ollama server's /api/generate
+// endpoint. It is the only provider that currently supports streaming.
+type OllamaProvider struct {
+	baseURL string
+	model   string
+	client  *http.Client
+}
+
+func (p *OllamaProvider) Model() string {
+	return p.model
+}
+
+func (p *OllamaProvider) Available() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", p.baseURL+"/api/tags", nil)
+	if err != nil {
+		logger.Error().Str("provider", "ollama").Err(err).Msg("error creating request to check availability")
+		return false
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		logger.Error().Str("provider", "ollama").Err(err).Msg("error checking availability")
+		return false
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode == http.StatusOK
+}
+
+func (p *OllamaProvider) Generate(ctx context.Context, student Student) (string, error) {
+	requestBody, err := json.Marshal(map[string]interface{}{
+		"model":  p.model,
+		"prompt": summaryPrompt(student),
+		"stream": false,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error marshaling request body: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/api/generate", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := doWithRetry(p.client, req)
+	if err != nil {
+		return "", fmt.Errorf("error making POST request to Ollama: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading response body: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Ollama API returned non-200 status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var chunk ollamaStreamChunk
+	if err := json.Unmarshal(body, &chunk); err != nil {
+		return "", fmt.Errorf("error decoding Ollama response: %v", err)
+	}
+
+	if chunk.Response == "" {
+		return "", fmt.Errorf("Ollama returned an empty summary")
+	}
+
+	return chunk.Response, nil
+}
+
+// Stream issues a streaming /api/generate request and invokes onChunk for
+// every token as it arrives, honoring ctx cancellation.
+func (p *OllamaProvider) Stream(ctx context.Context, student Student, onChunk func(chunk string) error) error {
+	requestBody, err := json.Marshal(map[string]interface{}{
+		"model":  p.model,
+		"prompt": summaryPrompt(student),
+		"stream": true,
+	})
+	if err != nil {
+		return fmt.Errorf("error marshaling request body: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/api/generate", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making POST request to Ollama: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("Ollama API returned non-200 status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var chunk ollamaStreamChunk
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			logger.Error().Str("request_id", requestIDFromContext(ctx)).Int("student_id", student.ID).Err(err).Msg("error decoding ollama stream chunk")
+			continue
+		}
+
+		if chunk.Response != "" {
+			if err := onChunk(chunk.Response); err != nil {
+				return err
+			}
+		}
+
+		if chunk.Done {
+			break
+		}
+	}
+
+	return scanner.Err()
+}
+
+// OpenAIProvider talks to an OpenAI-compatible /v1/chat/completions endpoint.
+type OpenAIProvider struct {
+	baseURL string
+	model   string
+	apiKey  string
+	client  *http.Client
+}
+
+func (p *OpenAIProvider) Model() string {
+	return p.model
+}
+
+func (p *OpenAIProvider) Generate(ctx context.Context, student Student) (string, error) {
+	requestBody, err := json.Marshal(map[string]interface{}{
+		"model": p.model,
+		"messages": []map[string]string{
+			{"role": "user", "content": summaryPrompt(student)},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("error marshaling request body: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/v1/chat/completions", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := doWithRetry(p.client, req)
+	if err != nil {
+		return "", fmt.Errorf("error making POST request to OpenAI: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading response body: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("OpenAI API returned non-200 status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("error decoding OpenAI response: %v", err)
+	}
+
+	if len(result.Choices) == 0 || result.Choices[0].Message.Content == "" {
+		return "", fmt.Errorf("OpenAI returned an empty summary")
+	}
+
+	return result.Choices[0].Message.Content, nil
+}
+
+// AnthropicProvider talks to the /v1/messages endpoint.
+type AnthropicProvider struct {
+	baseURL string
+	model   string
+	apiKey  string
+	client  *http.Client
+}
+
+func (p *AnthropicProvider) Model() string {
+	return p.model
+}
+
+func (p *AnthropicProvider) Generate(ctx context.Context, student Student) (string, error) {
+	requestBody, err := json.Marshal(map[string]interface{}{
+		"model":      p.model,
+		"max_tokens": 256,
+		"messages": []map[string]string{
+			{"role": "user", "content": summaryPrompt(student)},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("error marshaling request body: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/v1/messages", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", p.apiKey)
+	req.Header.Set("anthropic-version", "2023-06-01")
+
+	resp, err := doWithRetry(p.client, req)
+	if err != nil {
+		return "", fmt.Errorf("error making POST request to Anthropic: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading response body: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Anthropic API returned non-200 status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("error decoding Anthropic response: %v", err)
+	}
+
+	if len(result.Content) == 0 || result.Content[0].Text == "" {
+		return "", fmt.Errorf("Anthropic returned an empty summary")
+	}
+
+	return result.Content[0].Text, nil
+}
+
+// GoogleProvider talks to the Gemini generateContent endpoint.
+type GoogleProvider struct {
+	baseURL string
+	model   string
+	apiKey  string
+	client  *http.Client
+}
+
+func (p *GoogleProvider) Model() string {
+	return p.model
+}
+
+func (p *GoogleProvider) Generate(ctx context.Context, student Student) (string, error) {
+	requestBody, err := json.Marshal(map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{
+				"parts": []map[string]string{
+					{"text": summaryPrompt(student)},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("error marshaling request body: %v", err)
+	}
+
+	url := fmt.Sprintf("%s/v1beta/models/%s:generateContent?key=%s", p.baseURL, p.model, p.apiKey)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := doWithRetry(p.client, req)
+	if err != nil {
+		return "", fmt.Errorf("error making POST request to Google: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading response body: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Google API returned non-200 status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Candidates []struct {
+			Content struct {
+				Parts []struct {
+					Text string `json:"text"`
+				} `json:"parts"`
+			} `json:"content"`
+		} `json:"candidates"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("error decoding Google response: %v", err)
+	}
+
+	if len(result.Candidates) == 0 || len(result.Candidates[0].Content.Parts) == 0 || result.Candidates[0].Content.Parts[0].Text == "" {
+		return "", fmt.Errorf("Google returned an empty summary")
+	}
+
+	return result.Candidates[0].Content.Parts[0].Text, nil
+}
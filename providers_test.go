@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func testStudent() Student {
+	return Student{ID: 10000001, Name: "Alice", Age: 20, Email: "alice@example.com"}
+}
+
+func TestOllamaProviderGenerateParsesResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"response":"a bright student","done":true}`)
+	}))
+	defer srv.Close()
+
+	p := &OllamaProvider{baseURL: srv.URL, model: "llama2", client: srv.Client()}
+	got, err := p.Generate(context.Background(), testStudent())
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if got != "a bright student" {
+		t.Errorf("Generate = %q, want %q", got, "a bright student")
+	}
+}
+
+func TestOpenAIProviderGenerateParsesResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"a bright student"}}]}`)
+	}))
+	defer srv.Close()
+
+	p := &OpenAIProvider{baseURL: srv.URL, model: "gpt-3.5-turbo", apiKey: "test-key", client: srv.Client()}
+	got, err := p.Generate(context.Background(), testStudent())
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if got != "a bright student" {
+		t.Errorf("Generate = %q, want %q", got, "a bright student")
+	}
+}
+
+func TestOpenAIProviderGenerateEmptyChoices(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"choices":[]}`)
+	}))
+	defer srv.Close()
+
+	p := &OpenAIProvider{baseURL: srv.URL, model: "gpt-3.5-turbo", apiKey: "test-key", client: srv.Client()}
+	if _, err := p.Generate(context.Background(), testStudent()); err == nil {
+		t.Fatal("Generate with no choices: want error, got nil")
+	}
+}
+
+func TestAnthropicProviderGenerateParsesResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"content":[{"text":"a bright student"}]}`)
+	}))
+	defer srv.Close()
+
+	p := &AnthropicProvider{baseURL: srv.URL, model: "claude-3-haiku-20240307", apiKey: "test-key", client: srv.Client()}
+	got, err := p.Generate(context.Background(), testStudent())
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if got != "a bright student" {
+		t.Errorf("Generate = %q, want %q", got, "a bright student")
+	}
+}
+
+func TestGoogleProviderGenerateParsesResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"candidates":[{"content":{"parts":[{"text":"a bright student"}]}}]}`)
+	}))
+	defer srv.Close()
+
+	p := &GoogleProvider{baseURL: srv.URL, model: "gemini-1.5-flash", apiKey: "test-key", client: srv.Client()}
+	got, err := p.Generate(context.Background(), testStudent())
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if got != "a bright student" {
+		t.Errorf("Generate = %q, want %q", got, "a bright student")
+	}
+}
+
+func TestOllamaProviderStreamInvokesOnChunk(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"response":"a ","done":false}`)
+		fmt.Fprintln(w, `{"response":"bright student","done":false}`)
+		fmt.Fprintln(w, `{"response":"","done":true}`)
+	}))
+	defer srv.Close()
+
+	p := &OllamaProvider{baseURL: srv.URL, model: "llama2", client: srv.Client()}
+
+	var chunks []string
+	err := p.Stream(context.Background(), testStudent(), func(chunk string) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	if len(chunks) != 2 || chunks[0] != "a " || chunks[1] != "bright student" {
+		t.Errorf("chunks = %v, want [\"a \" \"bright student\"]", chunks)
+	}
+}
+
+func TestOllamaProviderStreamCancellation(t *testing.T) {
+	unblock := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+		fmt.Fprintln(w, `{"response":"a ","done":false}`)
+		flusher.Flush()
+		<-unblock
+	}))
+	defer srv.Close()
+	defer close(unblock)
+
+	p := &OllamaProvider{baseURL: srv.URL, model: "llama2", client: srv.Client()}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- p.Stream(ctx, testStudent(), func(chunk string) error {
+			select {
+			case <-first:
+			default:
+				close(first)
+				cancel()
+			}
+			return nil
+		})
+	}()
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Fatal("Stream after context cancellation: want error, got nil")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stream did not return after its context was cancelled")
+	}
+}
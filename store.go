@@ -0,0 +1,358 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+var (
+	ErrStudentNotFound = errors.New("student not found")
+	ErrStudentExists   = errors.New("student already exists")
+)
+
+// ListOptions controls pagination and filtering for Store.List.
+type ListOptions struct {
+	Limit, Offset  int
+	Name           string
+	MinAge, MaxAge int
+}
+
+// Store persists students. Implementations must be safe for concurrent use.
+type Store interface {
+	Create(ctx context.Context, student Student) error
+	Get(ctx context.Context, id int) (Student, error)
+	List(ctx context.Context, opts ListOptions) ([]Student, error)
+	Update(ctx context.Context, student Student) error
+	Delete(ctx context.Context, id int) error
+
+	// Stream calls fn once per student, ordered by ID, without
+	// materializing the full collection. It's used by the CSV/NDJSON/JSON
+	// export endpoint. Returning an error from fn stops iteration early.
+	Stream(ctx context.Context, fn func(Student) error) error
+}
+
+// newStore builds the Store selected by environment: a Postgres-backed store
+// when DATABASE_URL is a postgres(ql):// DSN, a SQLite-backed store
+// otherwise (STORE_PATH, default "students.db"), or an in-memory store when
+// STORE_DRIVER=memory is set explicitly.
+func newStore() (Store, error) {
+	if os.Getenv("STORE_DRIVER") == "memory" {
+		return NewInMemoryStore(), nil
+	}
+
+	dsn := os.Getenv("DATABASE_URL")
+	driver := "sqlite"
+	if dsn == "" {
+		path := os.Getenv("STORE_PATH")
+		if path == "" {
+			path = "students.db"
+		}
+		dsn = path
+	} else if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		driver = "postgres"
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %v", err)
+	}
+
+	store := &SQLStore{db: db, driver: driver}
+	if err := store.migrate(); err != nil {
+		return nil, fmt.Errorf("error migrating schema: %v", err)
+	}
+
+	return store, nil
+}
+
+// InMemoryStore keeps students in a map, matching the service's original
+// behavior. It's useful for tests and for STORE_DRIVER=memory.
+type InMemoryStore struct {
+	mu       sync.RWMutex
+	students map[int]Student
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{students: make(map[int]Student)}
+}
+
+func (s *InMemoryStore) Create(ctx context.Context, student Student) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.students[student.ID]; exists {
+		return ErrStudentExists
+	}
+	s.students[student.ID] = student
+	return nil
+}
+
+func (s *InMemoryStore) Get(ctx context.Context, id int) (Student, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	student, ok := s.students[id]
+	if !ok {
+		return Student{}, ErrStudentNotFound
+	}
+	return student, nil
+}
+
+func (s *InMemoryStore) List(ctx context.Context, opts ListOptions) ([]Student, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched := make([]Student, 0, len(s.students))
+	for _, student := range s.students {
+		if matchesFilter(student, opts) {
+			matched = append(matched, student)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID < matched[j].ID })
+
+	return paginate(matched, opts), nil
+}
+
+func (s *InMemoryStore) Update(ctx context.Context, student Student) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.students[student.ID]; !ok {
+		return ErrStudentNotFound
+	}
+	s.students[student.ID] = student
+	return nil
+}
+
+func (s *InMemoryStore) Stream(ctx context.Context, fn func(Student) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]int, 0, len(s.students))
+	for id := range s.students {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		if err := fn(s.students[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *InMemoryStore) Delete(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.students[id]; !ok {
+		return ErrStudentNotFound
+	}
+	delete(s.students, id)
+	return nil
+}
+
+func matchesFilter(student Student, opts ListOptions) bool {
+	if opts.Name != "" && !strings.Contains(strings.ToLower(student.Name), strings.ToLower(opts.Name)) {
+		return false
+	}
+	if opts.MinAge > 0 && student.Age < opts.MinAge {
+		return false
+	}
+	if opts.MaxAge > 0 && student.Age > opts.MaxAge {
+		return false
+	}
+	return true
+}
+
+func paginate(students []Student, opts ListOptions) []Student {
+	if opts.Offset >= len(students) {
+		return []Student{}
+	}
+	start := opts.Offset
+	end := len(students)
+	if opts.Limit > 0 && start+opts.Limit < end {
+		end = start + opts.Limit
+	}
+	return students[start:end]
+}
+
+// SQLStore is a database/sql-backed Store. It works against SQLite (the
+// default) and Postgres (when DATABASE_URL uses a postgres(ql):// DSN);
+// both drivers are registered via the blank imports above.
+type SQLStore struct {
+	db     *sql.DB
+	driver string
+}
+
+func (s *SQLStore) migrate() error {
+	_, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS students (
+			id    INTEGER PRIMARY KEY,
+			name  TEXT NOT NULL,
+			age   INTEGER NOT NULL,
+			email TEXT NOT NULL
+		)
+	`)
+	return err
+}
+
+// placeholder returns the driver-appropriate positional parameter for
+// argument index n (1-based): Postgres uses $1, $2, ...; SQLite uses ?.
+func (s *SQLStore) placeholder(n int) string {
+	if s.driver == "postgres" {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+// Create relies on the students.id primary key to reject duplicates
+// atomically, rather than checking existence before inserting: a
+// check-then-insert would race two concurrent creates of the same ID.
+func (s *SQLStore) Create(ctx context.Context, student Student) error {
+	query := fmt.Sprintf("INSERT INTO students (id, name, age, email) VALUES (%s, %s, %s, %s)",
+		s.placeholder(1), s.placeholder(2), s.placeholder(3), s.placeholder(4))
+	if _, err := s.db.ExecContext(ctx, query, student.ID, student.Name, student.Age, student.Email); err != nil {
+		if isUniqueViolation(err) {
+			return ErrStudentExists
+		}
+		return err
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether err is a primary-key/unique-constraint
+// violation from either supported driver, so callers can translate it into
+// ErrStudentExists instead of leaking a raw driver error to clients.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505"
+	}
+	return strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+func (s *SQLStore) Get(ctx context.Context, id int) (Student, error) {
+	query := fmt.Sprintf("SELECT id, name, age, email FROM students WHERE id = %s", s.placeholder(1))
+	row := s.db.QueryRowContext(ctx, query, id)
+
+	var student Student
+	if err := row.Scan(&student.ID, &student.Name, &student.Age, &student.Email); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Student{}, ErrStudentNotFound
+		}
+		return Student{}, err
+	}
+	return student, nil
+}
+
+func (s *SQLStore) List(ctx context.Context, opts ListOptions) ([]Student, error) {
+	query := "SELECT id, name, age, email FROM students WHERE 1=1"
+	var args []interface{}
+	n := 1
+
+	if opts.Name != "" {
+		query += fmt.Sprintf(" AND name LIKE %s", s.placeholder(n))
+		args = append(args, "%"+opts.Name+"%")
+		n++
+	}
+	if opts.MinAge > 0 {
+		query += fmt.Sprintf(" AND age >= %s", s.placeholder(n))
+		args = append(args, opts.MinAge)
+		n++
+	}
+	if opts.MaxAge > 0 {
+		query += fmt.Sprintf(" AND age <= %s", s.placeholder(n))
+		args = append(args, opts.MaxAge)
+		n++
+	}
+
+	query += " ORDER BY id"
+
+	if opts.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %s", s.placeholder(n))
+		args = append(args, opts.Limit)
+		n++
+	}
+	if opts.Offset > 0 {
+		query += fmt.Sprintf(" OFFSET %s", s.placeholder(n))
+		args = append(args, opts.Offset)
+		n++
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	students := make([]Student, 0)
+	for rows.Next() {
+		var student Student
+		if err := rows.Scan(&student.ID, &student.Name, &student.Age, &student.Email); err != nil {
+			return nil, err
+		}
+		students = append(students, student)
+	}
+	return students, rows.Err()
+}
+
+// Stream reads rows one at a time via rows.Next()/Scan, so exporting the
+// collection doesn't hold it all in memory at once.
+func (s *SQLStore) Stream(ctx context.Context, fn func(Student) error) error {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name, age, email FROM students ORDER BY id")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var student Student
+		if err := rows.Scan(&student.ID, &student.Name, &student.Age, &student.Email); err != nil {
+			return err
+		}
+		if err := fn(student); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *SQLStore) Update(ctx context.Context, student Student) error {
+	query := fmt.Sprintf("UPDATE students SET name = %s, age = %s, email = %s WHERE id = %s",
+		s.placeholder(1), s.placeholder(2), s.placeholder(3), s.placeholder(4))
+	res, err := s.db.ExecContext(ctx, query, student.Name, student.Age, student.Email, student.ID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrStudentNotFound
+	}
+	return nil
+}
+
+func (s *SQLStore) Delete(ctx context.Context, id int) error {
+	query := fmt.Sprintf("DELETE FROM students WHERE id = %s", s.placeholder(1))
+	res, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrStudentNotFound
+	}
+	return nil
+}
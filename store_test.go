@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+// newSQLiteTestStore opens an in-memory SQLite database with its schema
+// migrated, for exercising SQLStore without touching disk.
+func newSQLiteTestStore(t *testing.T) *SQLStore {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	store := &SQLStore{db: db, driver: "sqlite"}
+	if err := store.migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return store
+}
+
+// testStores returns one Store per backing implementation, so behavior
+// tests can run identically against both and catch parity drift.
+func testStores(t *testing.T) map[string]Store {
+	t.Helper()
+	return map[string]Store{
+		"memory": NewInMemoryStore(),
+		"sqlite": newSQLiteTestStore(t),
+	}
+}
+
+func TestStoreCreateGetUpdateDelete(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			student := Student{ID: 10000001, Name: "Alice", Age: 20, Email: "alice@example.com"}
+
+			if err := store.Create(ctx, student); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+
+			got, err := store.Get(ctx, student.ID)
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if got != student {
+				t.Errorf("Get = %+v, want %+v", got, student)
+			}
+
+			student.Age = 21
+			if err := store.Update(ctx, student); err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+			got, err = store.Get(ctx, student.ID)
+			if err != nil {
+				t.Fatalf("Get after Update: %v", err)
+			}
+			if got.Age != 21 {
+				t.Errorf("Age after Update = %d, want 21", got.Age)
+			}
+
+			if err := store.Delete(ctx, student.ID); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if _, err := store.Get(ctx, student.ID); !errors.Is(err, ErrStudentNotFound) {
+				t.Errorf("Get after Delete: err = %v, want ErrStudentNotFound", err)
+			}
+		})
+	}
+}
+
+func TestStoreCreateDuplicateID(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			student := Student{ID: 10000002, Name: "Bob", Age: 22, Email: "bob@example.com"}
+
+			if err := store.Create(ctx, student); err != nil {
+				t.Fatalf("first Create: %v", err)
+			}
+			if err := store.Create(ctx, student); !errors.Is(err, ErrStudentExists) {
+				t.Errorf("second Create: err = %v, want ErrStudentExists", err)
+			}
+		})
+	}
+}
+
+func TestStoreUpdateDeleteNotFound(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			missing := Student{ID: 10000003, Name: "Nobody", Age: 30, Email: "nobody@example.com"}
+
+			if err := store.Update(ctx, missing); !errors.Is(err, ErrStudentNotFound) {
+				t.Errorf("Update: err = %v, want ErrStudentNotFound", err)
+			}
+			if err := store.Delete(ctx, missing.ID); !errors.Is(err, ErrStudentNotFound) {
+				t.Errorf("Delete: err = %v, want ErrStudentNotFound", err)
+			}
+		})
+	}
+}
+
+func TestStoreStreamOrdersByID(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			want := []int{10000010, 10000011, 10000012}
+			for _, id := range []int{10000012, 10000010, 10000011} {
+				if err := store.Create(ctx, Student{ID: id, Name: "S", Age: 18, Email: "s@example.com"}); err != nil {
+					t.Fatalf("Create(%d): %v", id, err)
+				}
+			}
+
+			var got []int
+			if err := store.Stream(ctx, func(s Student) error {
+				got = append(got, s.ID)
+				return nil
+			}); err != nil {
+				t.Fatalf("Stream: %v", err)
+			}
+
+			if len(got) != len(want) {
+				t.Fatalf("Stream returned %d students, want %d", len(got), len(want))
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("Stream()[%d] = %d, want %d", i, got[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestStoreStreamStopsOnError(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			for _, id := range []int{10000020, 10000021} {
+				if err := store.Create(ctx, Student{ID: id, Name: "S", Age: 18, Email: "s@example.com"}); err != nil {
+					t.Fatalf("Create(%d): %v", id, err)
+				}
+			}
+
+			sentinel := errors.New("stop")
+			calls := 0
+			err := store.Stream(ctx, func(s Student) error {
+				calls++
+				return sentinel
+			})
+			if !errors.Is(err, sentinel) {
+				t.Fatalf("Stream error = %v, want sentinel", err)
+			}
+			if calls != 1 {
+				t.Errorf("fn called %d times, want 1 (stream should stop on error)", calls)
+			}
+		})
+	}
+}
+
+func TestIsUniqueViolationMatchesSQLiteMessage(t *testing.T) {
+	if !isUniqueViolation(errors.New("UNIQUE constraint failed: students.id")) {
+		t.Error("isUniqueViolation: want true for a SQLite unique-constraint error")
+	}
+	if isUniqueViolation(errors.New("some other error")) {
+		t.Error("isUniqueViolation: want false for an unrelated error")
+	}
+}